@@ -0,0 +1,118 @@
+package hijacker
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/golang/glog"
+)
+
+// certReloader lazily loads a TLS keypair from disk and keeps it cached in
+// memory, reloading it whenever the underlying files change. This allows
+// the webhook's certificate to be rotated (e.g. by cert-manager, or by a
+// renewer sidecar writing to a mounted secret volume) without restarting
+// the process and dropping in-flight admission reviews.
+type certReloader struct {
+	certFile string
+	keyFile  string
+	cert     atomic.Value // stores *tls.Certificate
+}
+
+// newCertReloader returns a certReloader with the keypair at certFile and
+// keyFile already loaded
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	cr := &certReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+	if err := cr.reload(); err != nil {
+		return nil, err
+	}
+	return cr, nil
+}
+
+// GetCertificate is suitable for use as a tls.Config's GetCertificate
+// callback. It always returns the most recently loaded certificate.
+func (c *certReloader) GetCertificate(
+	*tls.ClientHelloInfo,
+) (*tls.Certificate, error) {
+	return c.cert.Load().(*tls.Certificate), nil
+}
+
+// reload reads the keypair from disk and, if it is valid, atomically swaps
+// it in as the cached certificate. If the keypair cannot be loaded-- for
+// instance because a secret projection update is only partially written--
+// the previously cached certificate is left in place and an error is
+// returned so the caller cannot be tricked into serving a broken cert.
+func (c *certReloader) reload() error {
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("error loading TLS keypair: %s", err)
+	}
+	c.cert.Store(&cert)
+	return nil
+}
+
+// watch blocks, reloading the keypair whenever TLSCertFile, TLSKeyFile, or
+// their containing directories change, until the given context is canceled.
+// The containing directories are watched-- rather than the files themselves--
+// because Kubernetes secret volume projections update their contents by
+// atomically renaming a new directory into place, which most filesystem
+// watchers do not treat as an event on the original file.
+func (c *certReloader) watch(ctx context.Context) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		glog.Errorf("Error starting TLS certificate watcher: %s", err)
+		return
+	}
+	defer watcher.Close() // nolint: errcheck
+
+	dirs := []string{filepath.Dir(c.certFile)}
+	if keyDir := filepath.Dir(c.keyFile); keyDir != dirs[0] {
+		dirs = append(dirs, keyDir)
+	}
+	for _, dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			glog.Errorf("Error watching %s for TLS certificate changes: %s", dir, err)
+			return
+		}
+	}
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+				continue
+			}
+			if err := c.reload(); err != nil {
+				tlsReloadsTotal.WithLabelValues("failed").Inc()
+				glog.Errorf(
+					"Error reloading TLS certificate, continuing to serve the "+
+						"previously loaded certificate: %s",
+					err,
+				)
+				continue
+			}
+			tlsReloadsTotal.WithLabelValues("succeeded").Inc()
+			glog.Infof(
+				"Reloaded TLS certificate from %s and %s",
+				c.certFile,
+				c.keyFile,
+			)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			glog.Errorf("TLS certificate watcher error: %s", err)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package hijacker
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCertReloaderFallsBackOnBadReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+
+	writeSelfSignedCert(t, certFile, keyFile)
+
+	cr, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("unexpected error loading initial keypair: %s", err)
+	}
+	goodCert, _ := cr.GetCertificate(nil)
+
+	// Simulate a secret projection update that is only partially written
+	if err := os.WriteFile(certFile, []byte("not a certificate"), 0600); err != nil {
+		t.Fatalf("unexpected error writing truncated cert: %s", err)
+	}
+
+	if err := cr.reload(); err == nil {
+		t.Error("expected reload of a truncated cert to return an error")
+	}
+
+	stillServing, _ := cr.GetCertificate(nil)
+	if stillServing != goodCert {
+		t.Error("expected the previously loaded certificate to remain cached" +
+			" after a failed reload")
+	}
+}
+
+func writeSelfSignedCert(t *testing.T, certFile, keyFile string) {
+	t.Helper()
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error generating key: %s", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "osiris-hijacker-test"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+	}
+
+	der, err := x509.CreateCertificate(
+		rand.Reader,
+		template,
+		template,
+		&priv.PublicKey,
+		priv,
+	)
+	if err != nil {
+		t.Fatalf("unexpected error creating certificate: %s", err)
+	}
+
+	certOut, err := os.Create(certFile)
+	if err != nil {
+		t.Fatalf("unexpected error creating cert file: %s", err)
+	}
+	defer certOut.Close()
+	if err := pem.Encode(
+		certOut,
+		&pem.Block{Type: "CERTIFICATE", Bytes: der},
+	); err != nil {
+		t.Fatalf("unexpected error encoding certificate: %s", err)
+	}
+
+	keyBytes, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		t.Fatalf("unexpected error marshaling key: %s", err)
+	}
+	keyOut, err := os.Create(keyFile)
+	if err != nil {
+		t.Fatalf("unexpected error creating key file: %s", err)
+	}
+	defer keyOut.Close()
+	if err := pem.Encode(
+		keyOut,
+		&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes},
+	); err != nil {
+		t.Fatalf("unexpected error encoding key: %s", err)
+	}
+}
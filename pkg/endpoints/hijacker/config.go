@@ -0,0 +1,64 @@
+package hijacker
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeclientset "k8s.io/client-go/kubernetes"
+)
+
+// Config represents the complete set of configuration options for an
+// instance of the endpoints hijacking webhook
+type Config struct {
+	// SecurePort is the TLS port the mutating webhook server listens on
+	SecurePort int
+	// MetricsPort is the plain HTTP port Prometheus metrics (and a second
+	// copy of the `/healthz` check) are served on, so that scraping metrics
+	// does not require the webhook's client certificate
+	MetricsPort int
+	// TLSCertFile is the path to the webhook's TLS certificate
+	TLSCertFile string
+	// TLSKeyFile is the path to the webhook's TLS private key
+	TLSKeyFile string
+	// EnabledMutators is the list of registered mutator names that are
+	// permitted to run. A nil or empty slice enables all registered
+	// mutators, which preserves existing behavior for installations that
+	// do not set this option.
+	EnabledMutators []string
+	// AdditionalMutators is an extension point that allows downstream
+	// users of this package to register their own MutatorFuncs-- for
+	// instance, to mutate CRDs Osiris does not know about-- without
+	// forking the hijacker
+	AdditionalMutators []MutatorFunc
+	// KubeClient is used by the validating webhook to confirm that the
+	// Deployment or StatefulSet named by an Osiris-enabled service's
+	// annotations actually exists in the cluster
+	KubeClient kubeclientset.Interface
+	// AllowedNamespaces, if non-empty, restricts the hijacker to mutating
+	// objects in the listed namespaces. This is a second layer of defense
+	// alongside any namespaceSelector configured on the
+	// MutatingWebhookConfiguration itself, for installations that run one
+	// Osiris webhook across many tenants but only want it active for a
+	// subset of them.
+	AllowedNamespaces []string
+	// DeniedNamespaces, if non-empty, excludes the listed namespaces from
+	// mutation regardless of AllowedNamespaces. Denial always takes
+	// precedence over allowance.
+	DeniedNamespaces []string
+	// ObjectSelector, if set, restricts the hijacker to mutating objects
+	// whose labels match the selector
+	ObjectSelector *metav1.LabelSelector
+}
+
+// mutatorEnabled returns true if the named mutator is permitted to run
+// according to the Config's EnabledMutators list. An empty list enables
+// every mutator.
+func (c Config) mutatorEnabled(name string) bool {
+	if len(c.EnabledMutators) == 0 {
+		return true
+	}
+	for _, enabled := range c.EnabledMutators {
+		if enabled == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package hijacker
+
+import "testing"
+
+func TestConfigMutatorEnabled(t *testing.T) {
+	testCases := []struct {
+		name            string
+		enabledMutators []string
+		mutator         string
+		expected        bool
+	}{
+		{
+			name:            "empty list enables everything",
+			enabledMutators: nil,
+			mutator:         "service",
+			expected:        true,
+		},
+		{
+			name:            "mutator present in list",
+			enabledMutators: []string{"service", "deployment"},
+			mutator:         "deployment",
+			expected:        true,
+		},
+		{
+			name:            "mutator absent from list",
+			enabledMutators: []string{"service"},
+			mutator:         "statefulset",
+			expected:        false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := Config{EnabledMutators: tc.enabledMutators}
+			if actual := config.mutatorEnabled(tc.mutator); actual != tc.expected {
+				t.Errorf(
+					"mutatorEnabled(%q) = %t, expected %t",
+					tc.mutator,
+					actual,
+					tc.expected,
+				)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package hijacker
+
+import (
+	"errors"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// errFiltered is a sentinel returned by handleRequest's mutate closure when
+// a request is scoped out by Config.AllowedNamespaces, DeniedNamespaces, or
+// ObjectSelector, rather than by the matching mutator itself. serve treats
+// it as a distinct "filtered" decision instead of an error.
+var errFiltered = errors.New("request is out of scope for this hijacker")
+
+// inScope reports whether an object in the given namespace, with the given
+// labels, is within the scope this hijacker is configured to mutate. This
+// mirrors the restrictCertificatesToNamespace pattern used by other
+// admission controllers to let a single webhook installation serve many
+// tenants while only acting on a subset of them.
+func (h *hijacker) inScope(namespace string, objLabels map[string]string) bool {
+	for _, denied := range h.config.DeniedNamespaces {
+		if denied == namespace {
+			return false
+		}
+	}
+
+	if len(h.config.AllowedNamespaces) > 0 {
+		allowed := false
+		for _, ns := range h.config.AllowedNamespaces {
+			if ns == namespace {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+
+	if h.objectSelector != nil && !h.objectSelector.Matches(labels.Set(objLabels)) {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,96 @@
+package hijacker
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestHijackerInScope(t *testing.T) {
+	testCases := []struct {
+		name      string
+		config    Config
+		namespace string
+		labels    map[string]string
+		expected  bool
+	}{
+		{
+			name:      "no restrictions",
+			config:    Config{},
+			namespace: "default",
+			expected:  true,
+		},
+		{
+			name: "denied namespace",
+			config: Config{
+				DeniedNamespaces: []string{"kube-system"},
+			},
+			namespace: "kube-system",
+			expected:  false,
+		},
+		{
+			name: "denied takes precedence over allowed",
+			config: Config{
+				AllowedNamespaces: []string{"tenant-a"},
+				DeniedNamespaces:  []string{"tenant-a"},
+			},
+			namespace: "tenant-a",
+			expected:  false,
+		},
+		{
+			name: "namespace not in allow list",
+			config: Config{
+				AllowedNamespaces: []string{"tenant-a"},
+			},
+			namespace: "tenant-b",
+			expected:  false,
+		},
+		{
+			name: "namespace in allow list",
+			config: Config{
+				AllowedNamespaces: []string{"tenant-a", "tenant-b"},
+			},
+			namespace: "tenant-b",
+			expected:  true,
+		},
+		{
+			name:      "labels with no object selector configured",
+			config:    Config{},
+			namespace: "default",
+			labels:    map[string]string{"app": "osiris-enabled"},
+			expected:  true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := &hijacker{config: tc.config}
+			if actual := h.inScope(tc.namespace, tc.labels); actual != tc.expected {
+				t.Errorf(
+					"inScope(%q, %v) = %t, expected %t",
+					tc.namespace,
+					tc.labels,
+					actual,
+					tc.expected,
+				)
+			}
+		})
+	}
+}
+
+func TestHijackerInScopeObjectSelector(t *testing.T) {
+	h := &hijacker{config: Config{}}
+	selector, err := metav1.LabelSelectorAsSelector(
+		&metav1.LabelSelector{MatchLabels: map[string]string{"tier": "frontend"}},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error building selector: %s", err)
+	}
+	h.objectSelector = selector
+
+	if h.inScope("default", map[string]string{"tier": "backend"}) {
+		t.Error("expected non-matching labels to be out of scope")
+	}
+	if !h.inScope("default", map[string]string{"tier": "frontend"}) {
+		t.Error("expected matching labels to be in scope")
+	}
+}
@@ -2,16 +2,19 @@ package hijacker
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/golang/glog"
 	admissionsv1 "k8s.io/api/admission/v1"
-	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 
@@ -29,12 +32,16 @@ type Hijacker interface {
 }
 
 // hijacker is a component that handles webhook requests for patching
-// Osiris-enabled services in a manner that will permit the Osiris endpoints
-// controller to manage service endpoints
+// Osiris-enabled services in a manner that will permit the Osiris
+// endpoints controller to manage service endpoints
 type hijacker struct {
-	config       Config
-	deserializer runtime.Decoder
-	srv          *http.Server
+	config         Config
+	deserializer   runtime.Decoder
+	mutators       *mutatorRegistry
+	certReloader   *certReloader
+	objectSelector labels.Selector
+	srv            *http.Server
+	metricsSrv     *http.Server
 }
 
 // NewHijacker returns a new component that handles webhook requests for
@@ -43,18 +50,60 @@ type hijacker struct {
 func NewHijacker(config Config) Hijacker {
 	mux := http.NewServeMux()
 
+	certReloader, err := newCertReloader(config.TLSCertFile, config.TLSKeyFile)
+	if err != nil {
+		glog.Fatalf("Error loading TLS certificate: %s", err)
+	}
+
+	var objectSelector labels.Selector
+	if config.ObjectSelector != nil {
+		objectSelector, err = metav1.LabelSelectorAsSelector(config.ObjectSelector)
+		if err != nil {
+			glog.Fatalf("Error parsing ObjectSelector: %s", err)
+		}
+	}
+
 	h := &hijacker{
 		config: config,
 		deserializer: serializer.NewCodecFactory(
 			runtime.NewScheme(),
 		).UniversalDeserializer(),
+		mutators:       newMutatorRegistry(),
+		certReloader:   certReloader,
+		objectSelector: objectSelector,
 		srv: &http.Server{
 			Addr:    fmt.Sprintf(":%d", config.SecurePort),
 			Handler: mux,
+			TLSConfig: &tls.Config{
+				GetCertificate: certReloader.GetCertificate,
+			},
 		},
+		metricsSrv: newMetricsServer(config.MetricsPort),
+	}
+
+	registeredMutators := append(
+		[]MutatorFunc{
+			&serviceMutator{},
+			&deploymentMutator{},
+			&statefulSetMutator{},
+		},
+		config.AdditionalMutators...,
+	)
+	for _, fn := range registeredMutators {
+		if !config.mutatorEnabled(fn.Name()) {
+			continue
+		}
+		if err := h.mutators.register(fn); err != nil {
+			glog.Fatalf("Error registering %q mutator: %s", fn.Name(), err)
+		}
+		mux.HandleFunc(
+			fmt.Sprintf("/mutate/%s", fn.Name()),
+			h.handlerForMutator(fn),
+		)
 	}
 
 	mux.HandleFunc("/mutate", h.handleRequest)
+	mux.HandleFunc("/validate", h.handleValidate)
 	mux.HandleFunc("/healthz", healthz.HandleHealthCheckRequest)
 
 	return h
@@ -65,6 +114,15 @@ func NewHijacker(config Config) Hijacker {
 func (h *hijacker) Run(ctx context.Context) {
 	doneCh := make(chan struct{})
 
+	go h.certReloader.watch(ctx)
+
+	go func() {
+		glog.Infof("Endpoints hijacker metrics are listening on %s", h.metricsSrv.Addr)
+		if err := h.metricsSrv.ListenAndServe(); err != http.ErrServerClosed {
+			glog.Errorf("Endpoints hijacker metrics server error: %s", err)
+		}
+	}()
+
 	go func() {
 		select {
 		case <-ctx.Done(): // Context was canceled or expired
@@ -75,7 +133,8 @@ func (h *hijacker) Run(ctx context.Context) {
 				time.Second*5,
 			)
 			defer cancel()
-			h.srv.Shutdown(shutdownCtx) // nolint: errcheck
+			h.srv.Shutdown(shutdownCtx)        // nolint: errcheck
+			h.metricsSrv.Shutdown(shutdownCtx) // nolint: errcheck
 		case <-doneCh: // The server shut down on its own, perhaps due to error
 		}
 	}()
@@ -85,17 +144,105 @@ func (h *hijacker) Run(ctx context.Context) {
 			"services",
 		h.srv.Addr,
 	)
-	err := h.srv.ListenAndServeTLS(h.config.TLSCertFile, h.config.TLSKeyFile)
+	// Cert and key are already loaded into h.srv.TLSConfig via
+	// h.certReloader, so they are omitted here
+	err := h.srv.ListenAndServeTLS("", "")
 	if err != http.ErrServerClosed {
 		glog.Errorf("Endpoints hijacker error: %s", err)
 	}
 	close(doneCh)
 }
 
-// handleRequest serves requets to mutate an Osiris-enabed service
+// handlerForMutator returns an http.HandlerFunc that runs a single,
+// specific MutatorFunc regardless of the AdmissionRequest's kind. It backs
+// the per-mutator `/mutate/<name>` endpoints.
+func (h *hijacker) handlerForMutator(fn MutatorFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h.serve(w, r, func(ar *admissionsv1.AdmissionRequest) (
+			[]kubernetes.PatchOperation,
+			error,
+		) {
+			if err := h.decodeInScope(ar); err != nil {
+				return nil, err
+			}
+			return fn.Mutate(r.Context(), ar)
+		})
+	}
+}
+
+// handleRequest serves the combined `/mutate` endpoint. It dispatches on
+// ar.Kind.Kind, which the API server always sets on the AdmissionRequest
+// itself, so dispatch does not depend on Osiris knowing the object's Go
+// type in advance or on the embedded object repeating its own kind-- this
+// is what will let future handlers (e.g. for a ScaledResource CRD) be added
+// without this function changing-- and only then hands the request off to
+// the registered mutator for its kind, which is responsible for converting
+// to its own typed object.
 func (h *hijacker) handleRequest(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, func(ar *admissionsv1.AdmissionRequest) (
+		[]kubernetes.PatchOperation,
+		error,
+	) {
+		if err := h.decodeInScope(ar); err != nil {
+			return nil, err
+		}
+
+		// ar.Kind.Kind is set by the API server from the request's GVK and is
+		// always present, unlike the embedded object's own "kind" field, so
+		// dispatch is keyed on it rather than on anything decoded from
+		// ar.Object.Raw.
+		fn, ok := h.mutators.byKind[ar.Kind.Kind]
+		if !ok {
+			return nil, nil
+		}
+		return fn.Mutate(r.Context(), ar)
+	})
+}
+
+// decodeInScope returns errFiltered if ar's namespace and the embedded
+// object's labels put it out of scope per Config's AllowedNamespaces,
+// DeniedNamespaces, and ObjectSelector. It backs both the combined
+// `/mutate` endpoint and the per-mutator `/mutate/<name>` endpoints, so
+// scoping can't be bypassed by calling a specific mutator's endpoint
+// directly.
+func (h *hijacker) decodeInScope(ar *admissionsv1.AdmissionRequest) error {
+	obj := map[string]interface{}{}
+	if err := json.Unmarshal(ar.Object.Raw, &obj); err != nil {
+		return fmt.Errorf("could not unmarshal raw object: %s", err)
+	}
+	// NestedStringMap tolerates objects with no "metadata.labels" field
+	// instead of requiring one, unlike unstructured.Unstructured's own JSON
+	// decoding, which hard-requires a top-level "kind".
+	objLabels, _, err := unstructured.NestedStringMap(obj, "metadata", "labels")
+	if err != nil {
+		return fmt.Errorf("could not read object labels: %s", err)
+	}
+
+	// ar.Namespace is the namespace the API server authoritatively associates
+	// with this request, regardless of what (if anything) the submitted
+	// object's own metadata says-- the same field the audit/metrics code
+	// below trusts, so the scope check is made to agree with it.
+	if !h.inScope(ar.Namespace, objLabels) {
+		return errFiltered
+	}
+	return nil
+}
+
+// serve contains the request/response plumbing shared by the combined
+// `/mutate` endpoint and the per-mutator `/mutate/<name>` endpoints: reading
+// and decoding the AdmissionReview, invoking the supplied mutate function to
+// obtain patch operations, and writing back the resulting AdmissionReview
+func (h *hijacker) serve(
+	w http.ResponseWriter,
+	r *http.Request,
+	mutate func(*admissionsv1.AdmissionRequest) ([]kubernetes.PatchOperation, error),
+) {
 	defer r.Body.Close()
 
+	start := time.Now()
+	admissionsInFlight.Inc()
+	defer admissionsInFlight.Dec()
+
 	var body []byte
 	if r.Body != nil {
 		if data, err := ioutil.ReadAll(r.Body); err == nil {
@@ -123,32 +270,28 @@ func (h *hijacker) handleRequest(w http.ResponseWriter, r *http.Request) {
 	var patchOps []kubernetes.PatchOperation
 	var err error
 	ar := admissionsv1.AdmissionReview{}
+	filtered := false
 	if _, _, err = h.deserializer.Decode(body, nil, &ar); err != nil {
 		glog.Errorf("Can't decode body: %v", err)
 	} else {
-		svc := &corev1.Service{}
-		if err = json.Unmarshal(ar.Request.Object.Raw, svc); err != nil {
-			glog.Errorf("Could not unmarshal raw object: %v", err)
-		} else {
-			glog.Infof(
-				"AdmissionReview for Kind=%v, Namespace=%v Name=%v (%v) UID=%v "+
-					"patchOperation=%v UserInfo=%v",
-				ar.Request.Kind,
-				ar.Request.Namespace,
-				ar.Request.Name,
-				svc.Name,
-				ar.Request.UID,
-				ar.Request.Operation,
-				ar.Request.UserInfo,
-			)
-			if err = validateService(svc); err != nil {
-				glog.Errorf("Error validating service: %v", err)
-			} else {
-				patchOps, err = getServicePatchOperations(svc)
-			}
+		patchOps, err = mutate(ar.Request)
+		if err == errFiltered {
+			filtered = true
+			err = nil
+		} else if err != nil {
+			glog.Errorf("Error mutating %s: %v", ar.Request.Kind.Kind, err)
 		}
 	}
 
+	// dryRun indicates the caller only wants to know what this request would
+	// have done, e.g. `kubectl apply --dry-run=server`. Nothing about the
+	// patch computed above changes, since computing it has no side effects of
+	// its own; it is recorded below so that anything that does have a real
+	// side effect in the future can branch on it.
+	dryRun := ar.Request != nil &&
+		ar.Request.DryRun != nil &&
+		*ar.Request.DryRun
+
 	if err != nil {
 		admissionResponse = &admissionsv1.AdmissionResponse{
 			UID:     ar.Request.UID,
@@ -173,7 +316,6 @@ func (h *hijacker) handleRequest(w http.ResponseWriter, r *http.Request) {
 				},
 			}
 		} else {
-			glog.Infof("AdmissionResponse: patch=%v\n", string(patchBytes))
 			admissionResponse = &admissionsv1.AdmissionResponse{
 				UID:     ar.Request.UID,
 				Allowed: true,
@@ -186,6 +328,46 @@ func (h *hijacker) handleRequest(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Metrics and the audit log are themselves read-only observations, not
+	// the side effects dryRun is meant to suppress, so they are recorded for
+	// dry runs too-- tagged via the dry_run label/field-- rather than
+	// silently dropping the only record a dry-run admission ever leaves
+	// behind.
+	if ar.Request != nil {
+		decision := "allowed"
+		switch {
+		case filtered:
+			decision = "filtered"
+		case err != nil:
+			decision = "errored"
+		case len(patchOps) > 0:
+			decision = "patched"
+		}
+
+		admissionsTotal.WithLabelValues(
+			ar.Request.Kind.Kind,
+			string(ar.Request.Operation),
+			decision,
+			strconv.FormatBool(dryRun),
+		).Inc()
+		admissionDurationSeconds.WithLabelValues(ar.Request.Kind.Kind).Observe(
+			time.Since(start).Seconds(),
+		)
+
+		logAudit(auditLogEntry{
+			UID:          string(ar.Request.UID),
+			Kind:         ar.Request.Kind.Kind,
+			Namespace:    ar.Request.Namespace,
+			Name:         ar.Request.Name,
+			User:         ar.Request.UserInfo.Username,
+			Operation:    string(ar.Request.Operation),
+			Decision:     decision,
+			PatchOpCount: len(patchOps),
+			DurationSecs: time.Since(start).Seconds(),
+			DryRun:       dryRun,
+		})
+	}
+
 	admissionReview := admissionsv1.AdmissionReview{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "AdmissionReview",
@@ -213,20 +395,3 @@ func (h *hijacker) handleRequest(w http.ResponseWriter, r *http.Request) {
 		)
 	}
 }
-
-func validateService(svc *corev1.Service) error {
-	if kubernetes.ServiceIsEligibleForEndpointsManagement(svc.Annotations) {
-		_, deploymentPresent := svc.Annotations["osiris.dm.gg/deployment"]
-		_, statefulSetPresent := svc.Annotations["osiris.dm.gg/statefulset"]
-		if !deploymentPresent && !statefulSetPresent {
-			return fmt.Errorf(
-				`Osiris-enabled service %s in namespace %s is lacking the required `+
-					`"osiris.dm.gg/deployment" or`+
-					`"osiris.dm.gg/statefulset" annotation`,
-				svc.Name,
-				svc.Namespace,
-			)
-		}
-	}
-	return nil
-}
@@ -0,0 +1,99 @@
+package hijacker
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	admissionsv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func newTestHijacker(t *testing.T) *hijacker {
+	t.Helper()
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	writeSelfSignedCert(t, certFile, keyFile)
+
+	h := NewHijacker(Config{
+		TLSCertFile: certFile,
+		TLSKeyFile:  keyFile,
+	})
+	return h.(*hijacker)
+}
+
+func admissionReviewBody(t *testing.T, dryRun bool) []byte {
+	t.Helper()
+
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-svc", Namespace: "default"},
+	}
+	rawSvc, err := json.Marshal(svc)
+	if err != nil {
+		t.Fatalf("unexpected error marshaling service: %s", err)
+	}
+
+	ar := admissionsv1.AdmissionReview{
+		Request: &admissionsv1.AdmissionRequest{
+			UID:       "11111111-1111-1111-1111-111111111111",
+			Kind:      metav1.GroupVersionKind{Kind: "Service"},
+			Namespace: "default",
+			Name:      "my-svc",
+			Operation: admissionsv1.Create,
+			Object:    runtime.RawExtension{Raw: rawSvc},
+			DryRun:    &dryRun,
+		},
+	}
+
+	body, err := json.Marshal(ar)
+	if err != nil {
+		t.Fatalf("unexpected error marshaling admission review: %s", err)
+	}
+	return body
+}
+
+// TestHandleRequestRecordsMetricsOnDryRun exercises the fix for a
+// regression where `DryRun: true` admission reviews were silently excluded
+// from both the audit log and Prometheus metrics. It asserts on the
+// admissions counter only; the audit log line is not checked.
+func TestHandleRequestRecordsMetricsOnDryRun(t *testing.T) {
+	h := newTestHijacker(t)
+
+	before := testutil.ToFloat64(
+		admissionsTotal.WithLabelValues("Service", "CREATE", "allowed", "true"),
+	)
+
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/mutate",
+		bytes.NewReader(admissionReviewBody(t, true)),
+	)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	h.handleRequest(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	after := testutil.ToFloat64(
+		admissionsTotal.WithLabelValues("Service", "CREATE", "allowed", "true"),
+	)
+	if after != before+1 {
+		t.Errorf(
+			"expected a dry-run admission to still increment the metric, "+
+				"before=%v after=%v",
+			before,
+			after,
+		)
+	}
+}
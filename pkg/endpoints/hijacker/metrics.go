@@ -0,0 +1,90 @@
+package hijacker
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/golang/glog"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/dailymotion-oss/osiris/pkg/healthz"
+)
+
+var (
+	admissionsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "osiris_hijacker_admissions_total",
+			Help: "Count of admission reviews handled by the hijacker, by " +
+				"kind, operation, result, and whether it was a dry run",
+		},
+		[]string{"kind", "operation", "result", "dry_run"},
+	)
+
+	admissionDurationSeconds = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "osiris_hijacker_admission_duration_seconds",
+			Help: "Time taken to handle an admission review, by kind",
+		},
+		[]string{"kind"},
+	)
+
+	admissionsInFlight = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "osiris_hijacker_admissions_in_flight",
+			Help: "Number of admission reviews currently being handled",
+		},
+	)
+
+	tlsReloadsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "osiris_hijacker_tls_reload_total",
+			Help: "Count of TLS certificate reloads triggered by file changes, by result",
+		},
+		[]string{"result"},
+	)
+)
+
+// newMetricsServer returns an HTTP (non-TLS) server exposing Prometheus
+// metrics at `/metrics` and the same health check served by the webhook
+// server at `/healthz`, so that scraping the hijacker's metrics does not
+// require presenting the webhook's client certificate
+func newMetricsServer(port int) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/healthz", healthz.HandleHealthCheckRequest)
+	return &http.Server{
+		Addr:    fmt.Sprintf(":%d", port),
+		Handler: mux,
+	}
+}
+
+// auditLogEntry is marshaled to a single line of JSON and logged once per
+// admission review, in place of the ad-hoc glog.Infof audit lines that
+// handleRequest used to log. This shape is meant to be easy to ship into an
+// audit pipeline.
+type auditLogEntry struct {
+	UID          string  `json:"uid"`
+	Kind         string  `json:"kind"`
+	Namespace    string  `json:"namespace"`
+	Name         string  `json:"name"`
+	User         string  `json:"user"`
+	Operation    string  `json:"operation"`
+	Decision     string  `json:"decision"`
+	PatchOpCount int     `json:"patchOpCount"`
+	DurationSecs float64 `json:"durationSeconds"`
+	DryRun       bool    `json:"dryRun"`
+}
+
+func logAudit(entry auditLogEntry) {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		// This should never happen-- auditLogEntry contains only strings,
+		// ints, floats, and bools-- but a log line must never itself error
+		glog.Infof("admission audit: %+v", entry)
+		return
+	}
+	glog.Infof("admission audit: %s", b)
+}
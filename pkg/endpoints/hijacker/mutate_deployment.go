@@ -0,0 +1,39 @@
+package hijacker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	admissionsv1 "k8s.io/api/admission/v1"
+	appsv1 "k8s.io/api/apps/v1"
+
+	"github.com/dailymotion-oss/osiris/pkg/kubernetes"
+)
+
+// deploymentMutator is the MutatorFunc that patches Osiris-enabled
+// Deployments. See disableServiceLinksPatch for what it patches and why.
+type deploymentMutator struct{}
+
+func (d *deploymentMutator) Name() string {
+	return "deployment"
+}
+
+func (d *deploymentMutator) Kind() string {
+	return "Deployment"
+}
+
+func (d *deploymentMutator) Mutate(
+	ctx context.Context,
+	ar *admissionsv1.AdmissionRequest,
+) ([]kubernetes.PatchOperation, error) {
+	deployment := &appsv1.Deployment{}
+	if err := json.Unmarshal(ar.Object.Raw, deployment); err != nil {
+		return nil, fmt.Errorf("could not unmarshal raw object: %s", err)
+	}
+
+	return disableServiceLinksPatch(
+		deployment.Annotations,
+		deployment.Spec.Template.Spec.EnableServiceLinks,
+	), nil
+}
@@ -0,0 +1,76 @@
+package hijacker
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	admissionsv1 "k8s.io/api/admission/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestDeploymentMutatorMutate(t *testing.T) {
+	falseVal := false
+
+	testCases := []struct {
+		name        string
+		deployment  *appsv1.Deployment
+		expectPatch bool
+	}{
+		{
+			name: "not osiris-enabled",
+			deployment: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "my-deploy"},
+			},
+			expectPatch: false,
+		},
+		{
+			name: "osiris-enabled",
+			deployment: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "my-deploy",
+					Annotations: map[string]string{"osiris.dm.gg/enabled": "y"},
+				},
+			},
+			expectPatch: true,
+		},
+		{
+			name: "osiris-enabled but already disabled",
+			deployment: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "my-deploy",
+					Annotations: map[string]string{"osiris.dm.gg/enabled": "y"},
+				},
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{EnableServiceLinks: &falseVal},
+					},
+				},
+			},
+			expectPatch: false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			raw, err := json.Marshal(tc.deployment)
+			if err != nil {
+				t.Fatalf("unexpected error marshaling deployment: %s", err)
+			}
+
+			m := &deploymentMutator{}
+			patchOps, err := m.Mutate(context.Background(), &admissionsv1.AdmissionRequest{
+				Object: runtime.RawExtension{Raw: raw},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if tc.expectPatch != (len(patchOps) > 0) {
+				t.Errorf("expected patch=%t, got patchOps=%v", tc.expectPatch, patchOps)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package hijacker
+
+import "github.com/dailymotion-oss/osiris/pkg/kubernetes"
+
+// disableServiceLinksPatch returns the patch operation that sets
+// `enableServiceLinks: false` on a pod template, shared by deploymentMutator
+// and statefulSetMutator (and, in time, whatever mutator backs Osiris'
+// planned ScaledResource CRD): it is a no-op unless annotations carry an
+// Osiris annotation, and a no-op if enableServiceLinks is already set to
+// false, since the environment variables Kubernetes injects for every
+// Service in the namespace otherwise grow without bound as more
+// Osiris-managed services are added.
+func disableServiceLinksPatch(
+	annotations map[string]string,
+	enableServiceLinks *bool,
+) []kubernetes.PatchOperation {
+	if !kubernetes.ServiceIsEligibleForEndpointsManagement(annotations) {
+		return nil
+	}
+
+	if enableServiceLinks != nil && !*enableServiceLinks {
+		return nil
+	}
+
+	return []kubernetes.PatchOperation{
+		{
+			Op:    "add",
+			Path:  "/spec/template/spec/enableServiceLinks",
+			Value: false,
+		},
+	}
+}
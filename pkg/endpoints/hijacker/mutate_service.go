@@ -0,0 +1,59 @@
+package hijacker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	admissionsv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/dailymotion-oss/osiris/pkg/kubernetes"
+)
+
+// serviceMutator is the MutatorFunc that patches Osiris-enabled services in
+// a manner that permits the Osiris endpoints controller to manage their
+// endpoints. This is the original (and, prior to the introduction of the
+// mutator registry, only) behavior of the hijacker.
+type serviceMutator struct{}
+
+func (s *serviceMutator) Name() string {
+	return "service"
+}
+
+func (s *serviceMutator) Kind() string {
+	return "Service"
+}
+
+func (s *serviceMutator) Mutate(
+	ctx context.Context,
+	ar *admissionsv1.AdmissionRequest,
+) ([]kubernetes.PatchOperation, error) {
+	svc := &corev1.Service{}
+	if err := json.Unmarshal(ar.Object.Raw, svc); err != nil {
+		return nil, fmt.Errorf("could not unmarshal raw object: %s", err)
+	}
+
+	if err := validateService(svc); err != nil {
+		return nil, err
+	}
+
+	return getServicePatchOperations(svc)
+}
+
+func validateService(svc *corev1.Service) error {
+	if kubernetes.ServiceIsEligibleForEndpointsManagement(svc.Annotations) {
+		_, deploymentPresent := svc.Annotations["osiris.dm.gg/deployment"]
+		_, statefulSetPresent := svc.Annotations["osiris.dm.gg/statefulset"]
+		if !deploymentPresent && !statefulSetPresent {
+			return fmt.Errorf(
+				`Osiris-enabled service %s in namespace %s is lacking the required `+
+					`"osiris.dm.gg/deployment" or`+
+					`"osiris.dm.gg/statefulset" annotation`,
+				svc.Name,
+				svc.Namespace,
+			)
+		}
+	}
+	return nil
+}
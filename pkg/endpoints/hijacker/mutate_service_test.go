@@ -0,0 +1,78 @@
+package hijacker
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	admissionsv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestValidateService(t *testing.T) {
+	testCases := []struct {
+		name      string
+		svc       *corev1.Service
+		expectErr bool
+	}{
+		{
+			name:      "not osiris-enabled",
+			svc:       &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "my-svc"}},
+			expectErr: false,
+		},
+		{
+			name: "osiris-enabled with deployment annotation",
+			svc: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "my-svc",
+					Annotations: map[string]string{
+						"osiris.dm.gg/enabled":    "y",
+						"osiris.dm.gg/deployment": "my-deploy",
+					},
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "osiris-enabled without a deployment or statefulset annotation",
+			svc: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "my-svc",
+					Annotations: map[string]string{"osiris.dm.gg/enabled": "y"},
+				},
+			},
+			expectErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateService(tc.svc)
+			if tc.expectErr != (err != nil) {
+				t.Errorf("validateService() error = %v, expectErr %t", err, tc.expectErr)
+			}
+		})
+	}
+}
+
+func TestServiceMutatorMutateRejectsInvalidService(t *testing.T) {
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "my-svc",
+			Annotations: map[string]string{"osiris.dm.gg/enabled": "y"},
+		},
+	}
+	raw, err := json.Marshal(svc)
+	if err != nil {
+		t.Fatalf("unexpected error marshaling service: %s", err)
+	}
+
+	m := &serviceMutator{}
+	_, err = m.Mutate(context.Background(), &admissionsv1.AdmissionRequest{
+		Object: runtime.RawExtension{Raw: raw},
+	})
+	if err == nil {
+		t.Error("expected an error for a service missing the deployment/statefulset annotation")
+	}
+}
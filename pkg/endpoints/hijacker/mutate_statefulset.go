@@ -0,0 +1,39 @@
+package hijacker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	admissionsv1 "k8s.io/api/admission/v1"
+	appsv1 "k8s.io/api/apps/v1"
+
+	"github.com/dailymotion-oss/osiris/pkg/kubernetes"
+)
+
+// statefulSetMutator is the MutatorFunc that patches Osiris-enabled
+// StatefulSets. See disableServiceLinksPatch for what it patches and why.
+type statefulSetMutator struct{}
+
+func (s *statefulSetMutator) Name() string {
+	return "statefulset"
+}
+
+func (s *statefulSetMutator) Kind() string {
+	return "StatefulSet"
+}
+
+func (s *statefulSetMutator) Mutate(
+	ctx context.Context,
+	ar *admissionsv1.AdmissionRequest,
+) ([]kubernetes.PatchOperation, error) {
+	statefulSet := &appsv1.StatefulSet{}
+	if err := json.Unmarshal(ar.Object.Raw, statefulSet); err != nil {
+		return nil, fmt.Errorf("could not unmarshal raw object: %s", err)
+	}
+
+	return disableServiceLinksPatch(
+		statefulSet.Annotations,
+		statefulSet.Spec.Template.Spec.EnableServiceLinks,
+	), nil
+}
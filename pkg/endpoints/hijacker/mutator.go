@@ -0,0 +1,62 @@
+package hijacker
+
+import (
+	"context"
+	"fmt"
+
+	admissionsv1 "k8s.io/api/admission/v1"
+
+	"github.com/dailymotion-oss/osiris/pkg/kubernetes"
+)
+
+// MutatorFunc is implemented by the admission functions that are registered
+// with a hijacker. Each MutatorFunc declares the kind it applies to and knows
+// how to produce the JSON patch operations (if any) required to make an
+// admitted object Osiris-compliant. Only the kind name is declared and matched;
+// the API group and version of the admitted object are not considered.
+type MutatorFunc interface {
+	// Name returns the unique name this mutator is registered and mounted
+	// under, e.g. "service", "deployment", "statefulset"
+	Name() string
+	// Kind returns the Kubernetes kind this mutator applies to, e.g.
+	// "Service", "Deployment", "StatefulSet"
+	Kind() string
+	// Mutate inspects the given admission request and returns the patch
+	// operations required to make the underlying object Osiris-compliant
+	Mutate(
+		ctx context.Context,
+		ar *admissionsv1.AdmissionRequest,
+	) ([]kubernetes.PatchOperation, error)
+}
+
+// mutatorRegistry tracks the set of MutatorFuncs a hijacker has registered,
+// indexed both by name (for the per-mutator `/mutate/<name>` endpoints) and
+// by kind (for dispatching the combined `/mutate` endpoint)
+type mutatorRegistry struct {
+	byName map[string]MutatorFunc
+	byKind map[string]MutatorFunc
+}
+
+func newMutatorRegistry() *mutatorRegistry {
+	return &mutatorRegistry{
+		byName: map[string]MutatorFunc{},
+		byKind: map[string]MutatorFunc{},
+	}
+}
+
+// register adds a MutatorFunc to the registry. It is an error to register
+// two mutators with the same name or the same kind.
+func (m *mutatorRegistry) register(fn MutatorFunc) error {
+	if _, ok := m.byName[fn.Name()]; ok {
+		return fmt.Errorf("a mutator named %q is already registered", fn.Name())
+	}
+	if _, ok := m.byKind[fn.Kind()]; ok {
+		return fmt.Errorf(
+			"a mutator for kind %q is already registered",
+			fn.Kind(),
+		)
+	}
+	m.byName[fn.Name()] = fn
+	m.byKind[fn.Kind()] = fn
+	return nil
+}
@@ -0,0 +1,65 @@
+package hijacker
+
+import (
+	"context"
+	"testing"
+
+	admissionsv1 "k8s.io/api/admission/v1"
+
+	"github.com/dailymotion-oss/osiris/pkg/kubernetes"
+)
+
+// fakeMutator is a minimal MutatorFunc used only to exercise mutatorRegistry
+type fakeMutator struct {
+	name string
+	kind string
+}
+
+func (f *fakeMutator) Name() string {
+	return f.name
+}
+
+func (f *fakeMutator) Kind() string {
+	return f.kind
+}
+
+func (f *fakeMutator) Mutate(
+	context.Context,
+	*admissionsv1.AdmissionRequest,
+) ([]kubernetes.PatchOperation, error) {
+	return nil, nil
+}
+
+func TestMutatorRegistryRegister(t *testing.T) {
+	registry := newMutatorRegistry()
+
+	if err := registry.register(&fakeMutator{name: "service", kind: "Service"}); err != nil {
+		t.Fatalf("unexpected error registering first mutator: %s", err)
+	}
+
+	if err := registry.register(
+		&fakeMutator{name: "service", kind: "Widget"},
+	); err == nil {
+		t.Error("expected an error registering a duplicate name, got nil")
+	}
+
+	if err := registry.register(
+		&fakeMutator{name: "other", kind: "Service"},
+	); err == nil {
+		t.Error("expected an error registering a duplicate kind, got nil")
+	}
+
+	if err := registry.register(
+		&fakeMutator{name: "deployment", kind: "Deployment"},
+	); err != nil {
+		t.Errorf("unexpected error registering a distinct mutator: %s", err)
+	}
+
+	if len(registry.byName) != 2 || len(registry.byKind) != 2 {
+		t.Errorf(
+			"expected registry to contain 2 mutators, got byName=%d byKind=%d",
+			len(registry.byName),
+			len(registry.byKind),
+		)
+	}
+}
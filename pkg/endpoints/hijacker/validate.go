@@ -0,0 +1,199 @@
+package hijacker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/golang/glog"
+	admissionsv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// handleValidate serves the `/validate` endpoint, for use by a
+// ValidatingWebhookConfiguration separate from the mutating one mounted at
+// `/mutate`. It rejects an Osiris-enabled service lacking a required
+// annotation as an admission-policy failure. The service mutator behind
+// `/mutate` runs the same validateService check and rejects such a service
+// as well.
+func (h *hijacker) handleValidate(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var body []byte
+	if r.Body != nil {
+		if data, err := ioutil.ReadAll(r.Body); err == nil {
+			body = data
+		}
+	}
+	if len(body) == 0 {
+		glog.Error("empty body")
+		http.Error(w, "empty body", http.StatusBadRequest)
+		return
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	if contentType != "application/json" {
+		glog.Errorf("Content-Type=%s, expect application/json", contentType)
+		http.Error(
+			w,
+			"invalid Content-Type, expect `application/json`",
+			http.StatusUnsupportedMediaType,
+		)
+		return
+	}
+
+	ar := admissionsv1.AdmissionReview{}
+	var admissionResponse *admissionsv1.AdmissionResponse
+	if _, _, err := h.deserializer.Decode(body, nil, &ar); err != nil {
+		glog.Errorf("Can't decode body: %v", err)
+		admissionResponse = &admissionsv1.AdmissionResponse{
+			Allowed: false,
+			Result: &metav1.Status{
+				Message: err.Error(),
+			},
+		}
+	} else if ar.Request == nil {
+		glog.Error("AdmissionReview has no request")
+		admissionResponse = &admissionsv1.AdmissionResponse{
+			Allowed: false,
+			Result: &metav1.Status{
+				Message: "AdmissionReview has no request",
+			},
+		}
+	} else {
+		admissionResponse = h.runServiceValidation(r.Context(), ar.Request)
+	}
+
+	admissionReview := admissionsv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "AdmissionReview",
+			APIVersion: "admission.k8s.io/v1",
+		},
+		Response: admissionResponse,
+	}
+
+	resp, err := json.Marshal(admissionReview)
+	if err != nil {
+		glog.Errorf("Can't encode response: %v", err)
+		http.Error(
+			w,
+			fmt.Sprintf("could not encode response: %v", err),
+			http.StatusInternalServerError,
+		)
+		return
+	}
+	if _, err := w.Write(resp); err != nil {
+		glog.Errorf("Can't write response: %v", err)
+		http.Error(
+			w,
+			fmt.Sprintf("could not write response: %v", err),
+			http.StatusInternalServerError,
+		)
+	}
+}
+
+// runServiceValidation rejects Osiris-enabled services that are lacking the
+// required "osiris.dm.gg/deployment" or "osiris.dm.gg/statefulset"
+// annotation, and surfaces a warning (without rejecting) when that
+// annotation names a Deployment or StatefulSet that does not resolve in the
+// cluster
+func (h *hijacker) runServiceValidation(
+	ctx context.Context,
+	ar *admissionsv1.AdmissionRequest,
+) *admissionsv1.AdmissionResponse {
+	svc := &corev1.Service{}
+	if err := json.Unmarshal(ar.Object.Raw, svc); err != nil {
+		return &admissionsv1.AdmissionResponse{
+			UID:     ar.UID,
+			Allowed: false,
+			Result: &metav1.Status{
+				Message: fmt.Sprintf("could not unmarshal raw object: %s", err),
+			},
+		}
+	}
+
+	if err := validateService(svc); err != nil {
+		return &admissionsv1.AdmissionResponse{
+			UID:     ar.UID,
+			Allowed: false,
+			Result: &metav1.Status{
+				Message: err.Error(),
+			},
+		}
+	}
+
+	var warnings []string
+	if h.config.KubeClient != nil {
+		warnings = h.resourceExistenceWarnings(ctx, svc)
+	}
+
+	return &admissionsv1.AdmissionResponse{
+		UID:      ar.UID,
+		Allowed:  true,
+		Warnings: warnings,
+	}
+}
+
+// resourceExistenceWarnings returns a warning for each of svc's
+// "osiris.dm.gg/deployment" and "osiris.dm.gg/statefulset" annotations that
+// names a Deployment or StatefulSet not found in the service's namespace
+func (h *hijacker) resourceExistenceWarnings(
+	ctx context.Context,
+	svc *corev1.Service,
+) []string {
+	var warnings []string
+
+	if name, ok := svc.Annotations["osiris.dm.gg/deployment"]; ok {
+		_, err := h.config.KubeClient.AppsV1().Deployments(svc.Namespace).Get(
+			ctx,
+			name,
+			metav1.GetOptions{},
+		)
+		if apierrors.IsNotFound(err) {
+			warnings = append(warnings, fmt.Sprintf(
+				`service %s in namespace %s refers to Deployment %q, which `+
+					`does not exist`,
+				svc.Name,
+				svc.Namespace,
+				name,
+			))
+		} else if err != nil {
+			glog.Errorf(
+				"Error checking for existence of Deployment %s/%s: %s",
+				svc.Namespace,
+				name,
+				err,
+			)
+		}
+	}
+
+	if name, ok := svc.Annotations["osiris.dm.gg/statefulset"]; ok {
+		_, err := h.config.KubeClient.AppsV1().StatefulSets(svc.Namespace).Get(
+			ctx,
+			name,
+			metav1.GetOptions{},
+		)
+		if apierrors.IsNotFound(err) {
+			warnings = append(warnings, fmt.Sprintf(
+				`service %s in namespace %s refers to StatefulSet %q, which `+
+					`does not exist`,
+				svc.Name,
+				svc.Namespace,
+				name,
+			))
+		} else if err != nil {
+			glog.Errorf(
+				"Error checking for existence of StatefulSet %s/%s: %s",
+				svc.Namespace,
+				name,
+				err,
+			)
+		}
+	}
+
+	return warnings
+}
@@ -0,0 +1,25 @@
+package hijacker
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestHandleValidateNilRequest exercises the fix for a panic when a
+// syntactically valid AdmissionReview body decodes with a nil Request, e.g.
+// a POST body of `{}`
+func TestHandleValidateNilRequest(t *testing.T) {
+	h := newTestHijacker(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader([]byte("{}")))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	h.handleValidate(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+}